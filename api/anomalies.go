@@ -0,0 +1,200 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+)
+
+// isolationTree is a single random binary tree over feature vectors.
+type isolationTree struct {
+	splitFeature int
+	splitValue   float64
+	size         int
+	left, right  *isolationTree
+}
+
+func buildIsolationTree(samples [][]float64, depth, maxDepth int) *isolationTree {
+	if depth >= maxDepth || len(samples) <= 1 {
+		return &isolationTree{size: len(samples)}
+	}
+
+	feature := rand.Intn(len(samples[0]))
+	min, max := samples[0][feature], samples[0][feature]
+	for _, s := range samples {
+		if s[feature] < min {
+			min = s[feature]
+		}
+		if s[feature] > max {
+			max = s[feature]
+		}
+	}
+	if min == max {
+		return &isolationTree{size: len(samples)}
+	}
+
+	splitValue := min + rand.Float64()*(max-min)
+
+	var left, right [][]float64
+	for _, s := range samples {
+		if s[feature] < splitValue {
+			left = append(left, s)
+		} else {
+			right = append(right, s)
+		}
+	}
+	if len(left) == 0 || len(right) == 0 {
+		return &isolationTree{size: len(samples)}
+	}
+
+	return &isolationTree{
+		splitFeature: feature,
+		splitValue:   splitValue,
+		left:         buildIsolationTree(left, depth+1, maxDepth),
+		right:        buildIsolationTree(right, depth+1, maxDepth),
+	}
+}
+
+func (t *isolationTree) pathLength(x []float64, depth int) float64 {
+	if t.left == nil && t.right == nil {
+		return float64(depth) + averagePathLength(t.size)
+	}
+	if x[t.splitFeature] < t.splitValue {
+		return t.left.pathLength(x, depth+1)
+	}
+	return t.right.pathLength(x, depth+1)
+}
+
+// averagePathLength is c(n), used to normalize raw path lengths into scores.
+func averagePathLength(n int) float64 {
+	if n <= 1 {
+		return 0
+	}
+	return 2*harmonicNumber(n-1) - 2*float64(n-1)/float64(n)
+}
+
+func harmonicNumber(i int) float64 {
+	if i <= 0 {
+		return 0
+	}
+	return math.Log(float64(i)) + 0.5772156649
+}
+
+// IsolationForest scores feature vectors for anomalousness by averaging
+// isolation path length across an ensemble of random trees.
+type IsolationForest struct {
+	trees []*isolationTree
+	psi   int
+}
+
+// NewIsolationForest builds numTrees trees, each from a psi-sized subsample of data.
+func NewIsolationForest(data [][]float64, numTrees, psi int) *IsolationForest {
+	if psi > len(data) {
+		psi = len(data)
+	}
+	maxDepth := int(math.Ceil(math.Log2(float64(psi))))
+
+	trees := make([]*isolationTree, 0, numTrees)
+	for i := 0; i < numTrees; i++ {
+		trees = append(trees, buildIsolationTree(sampleWithoutReplacement(data, psi), 0, maxDepth))
+	}
+
+	return &IsolationForest{trees: trees, psi: psi}
+}
+
+func sampleWithoutReplacement(data [][]float64, size int) [][]float64 {
+	perm := rand.Perm(len(data))
+	if size > len(perm) {
+		size = len(perm)
+	}
+	sample := make([][]float64, size)
+	for i := 0; i < size; i++ {
+		sample[i] = data[perm[i]]
+	}
+	return sample
+}
+
+// Score returns s(x) = 2^(-E[h(x)]/c(psi)); closer to 1 is more anomalous.
+func (f *IsolationForest) Score(x []float64) float64 {
+	if len(f.trees) == 0 {
+		return 0
+	}
+
+	total := 0.0
+	for _, t := range f.trees {
+		total += t.pathLength(x, 0)
+	}
+	avg := total / float64(len(f.trees))
+
+	c := averagePathLength(f.psi)
+	if c == 0 {
+		return 0
+	}
+	return math.Pow(2, -avg/c)
+}
+
+type finishTimeAnomaly struct {
+	HorseID    int     `json:"horseId"`
+	FinishTime float64 `json:"finishTime"`
+	Score      float64 `json:"score"`
+}
+
+func anomaliesHandler(w http.ResponseWriter, r *http.Request) {
+	numTrees := 100
+	if v := r.URL.Query().Get("trees"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			numTrees = n
+		}
+	}
+
+	psi := 256
+	if v := r.URL.Query().Get("psi"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			psi = n
+		}
+	}
+
+	threshold := 0.6
+	if v := r.URL.Query().Get("threshold"); v != "" {
+		if t, err := strconv.ParseFloat(v, 64); err == nil {
+			threshold = t
+		}
+	}
+
+	races, err := allRaces()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to load races: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	anomalies := []finishTimeAnomaly{}
+	for _, race := range races {
+		if len(race.finishTimes) == 0 {
+			continue
+		}
+
+		// 1-D for now (finish time only); entryFee etc. can be appended later.
+		data := make([][]float64, len(race.finishTimes))
+		for i, t := range race.finishTimes {
+			data[i] = []float64{t}
+		}
+
+		forest := NewIsolationForest(data, numTrees, psi)
+		for i, t := range race.finishTimes {
+			score := forest.Score(data[i])
+			if score > threshold {
+				anomalies = append(anomalies, finishTimeAnomaly{
+					HorseID:    race.horseId,
+					FinishTime: t,
+					Score:      score,
+				})
+			}
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(anomalies)
+}
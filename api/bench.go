@@ -0,0 +1,193 @@
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// runBench implements the `bench` subcommand: it replays a CSV of horse
+// pairs against a running server at a configurable concurrency, records
+// per-call latencies, and writes a percentile summary plus an optional pair
+// of CPU/heap pprof profiles.
+func runBench(args []string) error {
+	fs := flag.NewFlagSet("bench", flag.ExitOnError)
+	csvPath := fs.String("csv", "bench-pairs.csv", "CSV of horse1,horse2 pairs to replay (not the races-for-tay.csv dataset, which has no such columns)")
+	url := fs.String("url", "http://localhost:8080/compare", "endpoint to bench")
+	concurrency := fs.Int("concurrency", 10, "number of concurrent workers")
+	outPath := fs.String("out", "bench-results.csv", "path to write the per-call latency CSV")
+	cpuProfile := fs.String("cpuprofile", "", "write a CPU profile to this file")
+	memProfile := fs.String("memprofile", "", "write a heap profile to this file")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *cpuProfile != "" {
+		f, err := os.Create(*cpuProfile)
+		if err != nil {
+			return fmt.Errorf("failed to create cpu profile: %v", err)
+		}
+		defer f.Close()
+		if err := pprof.StartCPUProfile(f); err != nil {
+			return fmt.Errorf("failed to start cpu profile: %v", err)
+		}
+		defer pprof.StopCPUProfile()
+	}
+
+	pairs, err := readHorsePairs(*csvPath)
+	if err != nil {
+		return fmt.Errorf("failed to read pairs: %v", err)
+	}
+
+	jobs := make(chan [2]int, len(pairs))
+	for _, p := range pairs {
+		jobs <- p
+	}
+	close(jobs)
+
+	client := &http.Client{Timeout: 10 * time.Second}
+
+	latencies := make([]float64, 0, len(pairs))
+	var failures int
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for i := 0; i < *concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for p := range jobs {
+				start := time.Now()
+				resp, err := client.Get(fmt.Sprintf("%s?horse1=%d&horse2=%d", *url, p[0], p[1]))
+				if err != nil {
+					mu.Lock()
+					failures++
+					mu.Unlock()
+					continue
+				}
+				resp.Body.Close()
+				elapsed := time.Since(start).Seconds()
+
+				mu.Lock()
+				if resp.StatusCode >= 400 {
+					failures++
+				} else {
+					latencies = append(latencies, elapsed)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	sort.Float64s(latencies)
+	p50 := percentileOf(latencies, 0.5)
+	p95 := percentileOf(latencies, 0.95)
+	p99 := percentileOf(latencies, 0.99)
+
+	fmt.Printf("calls=%d failures=%d p50=%.4fs p95=%.4fs p99=%.4fs\n", len(latencies), failures, p50, p95, p99)
+
+	if *memProfile != "" {
+		f, err := os.Create(*memProfile)
+		if err != nil {
+			return fmt.Errorf("failed to create heap profile: %v", err)
+		}
+		defer f.Close()
+		runtime.GC()
+		if err := pprof.WriteHeapProfile(f); err != nil {
+			return fmt.Errorf("failed to write heap profile: %v", err)
+		}
+	}
+
+	return writeBenchSummary(*outPath, latencies)
+}
+
+// readHorsePairs reads a CSV with a header row containing horse1,horse2
+// columns. It fails loudly (rather than silently returning zero pairs) if
+// those columns aren't present, since that usually means the wrong file was
+// passed via -csv — e.g. the live races-for-tay.csv dataset, which is keyed
+// by horseId,entryFee,finishTime instead.
+func readHorsePairs(path string) ([][2]int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header: %v", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[name] = i
+	}
+	h1Col, ok := columns["horse1"]
+	if !ok {
+		return nil, fmt.Errorf("%s has no horse1 column (found %v) — pass a CSV of horse1,horse2 pairs via -csv", path, header)
+	}
+	h2Col, ok := columns["horse2"]
+	if !ok {
+		return nil, fmt.Errorf("%s has no horse2 column (found %v) — pass a CSV of horse1,horse2 pairs via -csv", path, header)
+	}
+
+	var pairs [][2]int
+	for {
+		line, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(line) <= h1Col || len(line) <= h2Col {
+			continue
+		}
+
+		h1, err := strconv.Atoi(line[h1Col])
+		if err != nil {
+			continue
+		}
+		h2, err := strconv.Atoi(line[h2Col])
+		if err != nil {
+			continue
+		}
+		pairs = append(pairs, [2]int{h1, h2})
+	}
+
+	return pairs, nil
+}
+
+func writeBenchSummary(path string, latencies []float64) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	defer writer.Flush()
+
+	if err := writer.Write([]string{"call", "latencySeconds"}); err != nil {
+		return err
+	}
+	for i, l := range latencies {
+		if err := writer.Write([]string{strconv.Itoa(i), strconv.FormatFloat(l, 'f', 6, 64)}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
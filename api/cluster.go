@@ -0,0 +1,222 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+	"net/http"
+	"sort"
+	"strconv"
+)
+
+// horseFeatureVector derives a fixed-size feature vector for a horse from
+// its finish times and entry fee: mean, stddev, min, p50, p90, entryFee.
+func horseFeatureVector(race Race) []float64 {
+	if len(race.finishTimes) == 0 {
+		return []float64{0, 0, 0, 0, 0, race.entryFee}
+	}
+
+	sorted := append([]float64(nil), race.finishTimes...)
+	sort.Float64s(sorted)
+
+	mean := meanOf(sorted)
+	stddev := stddevOf(sorted, mean)
+
+	return []float64{
+		mean,
+		stddev,
+		sorted[0],
+		percentileOf(sorted, 0.5),
+		percentileOf(sorted, 0.9),
+		race.entryFee,
+	}
+}
+
+func meanOf(xs []float64) float64 {
+	sum := 0.0
+	for _, x := range xs {
+		sum += x
+	}
+	return sum / float64(len(xs))
+}
+
+func stddevOf(xs []float64, mean float64) float64 {
+	if len(xs) < 2 {
+		return 0
+	}
+	sumSquares := 0.0
+	for _, x := range xs {
+		d := x - mean
+		sumSquares += d * d
+	}
+	return math.Sqrt(sumSquares / float64(len(xs)))
+}
+
+// percentileOf returns the value at the given percentile (0-1) of a sorted
+// slice using nearest-rank interpolation.
+func percentileOf(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	idx := int(p * float64(len(sorted)-1))
+	return sorted[idx]
+}
+
+func squaredDistance(a, b []float64) float64 {
+	sum := 0.0
+	for i := range a {
+		d := a[i] - b[i]
+		sum += d * d
+	}
+	return sum
+}
+
+// kmeansPlusPlusSeed picks k centroids, weighted by squared distance to the
+// nearest already-chosen centroid.
+func kmeansPlusPlusSeed(points [][]float64, k int) [][]float64 {
+	n := len(points)
+	centroids := make([][]float64, 0, k)
+
+	first := points[rand.Intn(n)]
+	centroids = append(centroids, first)
+
+	d2 := make([]float64, n)
+	for i, p := range points {
+		d2[i] = squaredDistance(p, first)
+	}
+
+	for len(centroids) < k {
+		dSum := make([]float64, n)
+		sum := 0.0
+		for i, d := range d2 {
+			sum += d
+			dSum[i] = sum
+		}
+
+		var next []float64
+		if sum == 0 {
+			next = points[rand.Intn(n)]
+		} else {
+			idx := sort.SearchFloat64s(dSum, rand.Float64()*sum)
+			if idx >= n {
+				idx = n - 1
+			}
+			next = points[idx]
+		}
+		centroids = append(centroids, next)
+
+		for i, p := range points {
+			if d := squaredDistance(p, next); d < d2[i] {
+				d2[i] = d
+			}
+		}
+	}
+
+	return centroids
+}
+
+// kmeans runs Lloyd's algorithm to convergence (or maxIterations, whichever
+// comes first) starting from a KMeans++ seed.
+func kmeans(points [][]float64, k, maxIterations int) ([][]float64, []int) {
+	centroids := kmeansPlusPlusSeed(points, k)
+	assignments := make([]int, len(points))
+
+	for iter := 0; iter < maxIterations; iter++ {
+		changed := false
+		for i, p := range points {
+			best := 0
+			bestDist := squaredDistance(p, centroids[0])
+			for c := 1; c < k; c++ {
+				if d := squaredDistance(p, centroids[c]); d < bestDist {
+					bestDist = d
+					best = c
+				}
+			}
+			if assignments[i] != best {
+				changed = true
+				assignments[i] = best
+			}
+		}
+
+		sums := make([][]float64, k)
+		counts := make([]int, k)
+		for c := range sums {
+			sums[c] = make([]float64, len(points[0]))
+		}
+		for i, p := range points {
+			c := assignments[i]
+			counts[c]++
+			for d, v := range p {
+				sums[c][d] += v
+			}
+		}
+		for c := range sums {
+			if counts[c] == 0 {
+				sums[c] = centroids[c]
+				continue
+			}
+			for d := range sums[c] {
+				sums[c][d] /= float64(counts[c])
+			}
+		}
+		centroids = sums
+
+		if !changed {
+			break
+		}
+	}
+
+	return centroids, assignments
+}
+
+type clusterAssignment struct {
+	HorseID int `json:"horseId"`
+	Cluster int `json:"cluster"`
+}
+
+type clustersResponse struct {
+	K           int                 `json:"k"`
+	Assignments []clusterAssignment `json:"assignments"`
+	Centroids   [][]float64         `json:"centroids"`
+}
+
+func clustersHandler(w http.ResponseWriter, r *http.Request) {
+	races, err := allRaces()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to load races: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	k := 3
+	if v := r.URL.Query().Get("k"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			k = n
+		}
+	}
+	if k > len(races) {
+		k = len(races)
+	}
+	if k == 0 {
+		http.Error(w, "no races loaded", http.StatusInternalServerError)
+		return
+	}
+
+	points := make([][]float64, len(races))
+	for i, race := range races {
+		points[i] = horseFeatureVector(race)
+	}
+
+	centroids, assignments := kmeans(points, k, 100)
+
+	response := clustersResponse{K: k, Centroids: centroids}
+	for i, race := range races {
+		response.Assignments = append(response.Assignments, clusterAssignment{
+			HorseID: race.horseId,
+			Cluster: assignments[i],
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
@@ -7,114 +7,48 @@ import (
 	"os"
 	"sort"
 	"strconv"
-	"io"
-	"encoding/csv"
 	"time"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"monte/store"
 )
 
+// Race is the in-handler view of a horse's recorded races, assembled on
+// demand from db rather than kept as a standing in-memory slice.
 type Race struct {
 	horseId     int
 	entryFee    float64
 	finishTimes []float64
 }
 
-var races []Race
-
-type racesByHorseID []Race
-
-func (r racesByHorseID) Len() int {
-	return len(r)
-}
-
-func (r racesByHorseID) Swap(i, j int) {
-	r[i], r[j] = r[j], r[i]
-}
+var db *store.Store
 
-func (r racesByHorseID) Less(i, j int) bool {
-	return r[i].horseId < r[j].horseId
+func raceFromStoreRace(r store.Race) Race {
+	return Race{horseId: r.HorseID, entryFee: r.EntryFee, finishTimes: r.FinishTimes}
 }
 
-// Modify the Race struct to initialize the finishTimes slice with a length of 0.
-func NewRace(horseId int, entryFee float64) *Race {
-	return &Race{
-		horseId:     horseId,
-		entryFee:    entryFee,
-		finishTimes: make([]float64, 0),
-	}
-}
-
-
-func readRacesCSV(filePath string) error {
-	file, err := os.Open(filePath)
+// raceByID fetches a single horse's race directly from db, replacing the
+// old O(n) linear scan over a shared slice.
+func raceByID(horseID int) (Race, error) {
+	r, err := db.GetRace(horseID)
 	if err != nil {
-		return fmt.Errorf("failed to open file: %v", err)
+		return Race{}, err
 	}
-	defer file.Close()
-
-	reader := csv.NewReader(file)
-	reader.FieldsPerRecord = -1 // to allow variable number of fields
-	reader.TrimLeadingSpace = true
-
-	var header []string
-	for i := 0; ; i++ {
-		line, err := reader.Read()
-		if err != nil {
-			if err == io.EOF {
-				break
-			}
-			return fmt.Errorf("failed to read file: %v", err)
-		}
-
-		if i == 0 {
-			header = line
-			continue
-		}
-
-		record := make(map[string]string)
-		for i, field := range line {
-			record[header[i]] = field
-		}
-
-		horseID, err := strconv.Atoi(record["horseId"])
-		if err != nil {
-			return fmt.Errorf("failed to parse horseId: %v", err)
-		}
-
-		entryFee, err := strconv.ParseFloat(record["entryFee"], 64)
-		if err != nil {
-			return fmt.Errorf("failed to parse entryFee: %v", err)
-		}
-
-		finishTime, err := strconv.ParseFloat(record["finishTime"], 64)
-		if err != nil {
-			return fmt.Errorf("failed to parse finishTime: %v", err)
-		}
-
-		var race *Race
-		for i := range races {
-			if races[i].horseId == horseID {
-				race = &races[i]
-				break
-			}
-		}
-
-		if race == nil {
-			// Use NewRace function to create a new Race instance with empty finishTimes slice.
-			race = NewRace(horseID, entryFee)
-			races = append(races, *race)
-		}
-
-		race.finishTimes = append(race.finishTimes, finishTime)
-		
-	}
-
-	for i := range races {
-		sort.Float64s(races[i].finishTimes)
-	}
-
-	return nil
+	return raceFromStoreRace(r), nil
 }
 
+// allRaces snapshots every horse's race from db for handlers that need to
+// consider the whole dataset at once (anomaly detection, clustering,
+// training).
+func allRaces() ([]Race, error) {
+	var out []Race
+	err := db.Iterate(func(r store.Race) error {
+		out = append(out, raceFromStoreRace(r))
+		return nil
+	})
+	return out, err
+}
 
 func calculateMeanSpeed(horseSpeeds []float64, numSamples int) float64 {
 	if len(horseSpeeds) == 0 {
@@ -169,7 +103,6 @@ func getFasterHorse(race Race) (int, float64) {
 	return fasterHorse, fastest
 }
 
-
 func getSlowerHorse(race Race) (int, float64) {
 	if len(race.finishTimes) == 0 {
 		return 0, 0
@@ -203,13 +136,21 @@ func compareMeanSpeed(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Find races for each horse
-	h1Races := racesByHorseID(races)[h1-1]
-	h2Races := racesByHorseID(races)[h2-1]
+	h1Race, err := raceByID(h1)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("horse1 not found: %v", err), http.StatusNotFound)
+		return
+	}
+	h2Race, err := raceByID(h2)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("horse2 not found: %v", err), http.StatusNotFound)
+		return
+	}
 
 	// Calculate mean speed for each horse
 	numSamples := 1000
-	h1MeanSpeed := calculateMeanSpeed(h1Races.finishTimes, numSamples)
-	h2MeanSpeed := calculateMeanSpeed(h2Races.finishTimes, numSamples)
+	h1MeanSpeed := calculateMeanSpeed(h1Race.finishTimes, numSamples)
+	h2MeanSpeed := calculateMeanSpeed(h2Race.finishTimes, numSamples)
 
 	// Determine which horse is faster
 	var fasterHorse int
@@ -228,6 +169,12 @@ func compareMeanSpeed(w http.ResponseWriter, r *http.Request) {
 		slowerSpeed = h1MeanSpeed
 	}
 
+	outcome := "horse1"
+	if fasterHorse == h2 {
+		outcome = "horse2"
+	}
+	comparisonsTotal.WithLabelValues(strconv.Itoa(h1), strconv.Itoa(h2), outcome).Inc()
+
 	// Construct response JSON
 	response := fmt.Sprintf(`{"fasterHorse": %d, "slowerHorse": %d, "fasterSpeed": %f, "slowerSpeed": %f}`,
 		fasterHorse, slowerHorse, fasterSpeed, slowerSpeed)
@@ -237,20 +184,44 @@ func compareMeanSpeed(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte(response))
 }
 
-
 func main() {
-	err := readRacesCSV("races-for-tay.csv")
+	if len(os.Args) > 1 && os.Args[1] == "bench" {
+		if err := runBench(os.Args[2:]); err != nil {
+			fmt.Printf("bench error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	var err error
+	db, err = store.Open("races.db")
 	if err != nil {
 		panic(err)
 	}
+	defer db.Close()
 
-	err = readRacesCSV("races-for-tay.csv")
+	// Ingester.Poll is idempotent against its persisted offset, so calling
+	// it once here both bulk-loads a fresh store and catches a restarted
+	// one up on rows appended since it last ran, without re-storing
+	// anything already durable.
+	ingester, err := store.NewIngester(db, "races-for-tay.csv")
 	if err != nil {
-		fmt.Printf("Error reading races CSV: %v", err)
-		return
+		panic(err)
 	}
+	if err := ingester.Poll(); err != nil {
+		panic(err)
+	}
+	go ingester.Watch(5*time.Second, nil)
+
+	refreshDatasetGauges()
+	go watchDatasetGauges(30 * time.Second)
 
-	http.HandleFunc("/compare", compareMeanSpeed)
+	http.HandleFunc("/compare", instrumented("compare", compareMeanSpeed))
+	http.HandleFunc("/anomalies", instrumented("anomalies", anomaliesHandler))
+	http.HandleFunc("/train", instrumented("train", trainHandler))
+	http.HandleFunc("/predict", instrumented("predict", predictWinHandler))
+	http.HandleFunc("/clusters", instrumented("clusters", clustersHandler))
+	http.Handle("/metrics", promhttp.Handler())
 
 	fmt.Println("Starting server...")
 	http.ListenAndServe(":8080", nil)
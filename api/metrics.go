@@ -0,0 +1,77 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"monte/store"
+)
+
+var (
+	requestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "monte_request_duration_seconds",
+		Help:    "Latency of HTTP handlers, in seconds.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"handler"})
+
+	comparisonsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "monte_comparisons_total",
+		Help: "Number of /compare requests by horse pair and outcome.",
+	}, []string{"horse1", "horse2", "outcome"})
+
+	datasetHorses = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "monte_dataset_horses",
+		Help: "Number of horses with at least one recorded race.",
+	})
+
+	// One race run per recorded finish time, so this tracks datasetFinishTimes.
+	datasetRaces = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "monte_dataset_races",
+		Help: "Total number of recorded race runs across all horses.",
+	})
+
+	datasetFinishTimes = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "monte_dataset_finish_times",
+		Help: "Total number of recorded finish times across all horses.",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(requestDuration, comparisonsTotal, datasetHorses, datasetRaces, datasetFinishTimes)
+}
+
+// instrumented wraps a handler so every call records its latency against
+// requestDuration under the given label.
+func instrumented(label string, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		handler(w, r)
+		requestDuration.WithLabelValues(label).Observe(time.Since(start).Seconds())
+	}
+}
+
+// refreshDatasetGauges recomputes the dataset-size gauges from db. It is
+// cheap enough to call on a timer rather than per-request.
+func refreshDatasetGauges() {
+	var horses, finishTimes float64
+	db.Iterate(func(r store.Race) error {
+		horses++
+		finishTimes += float64(len(r.FinishTimes))
+		return nil
+	})
+	datasetHorses.Set(horses)
+	datasetRaces.Set(finishTimes)
+	datasetFinishTimes.Set(finishTimes)
+}
+
+// watchDatasetGauges periodically refreshes the dataset-size gauges so
+// /metrics reflects ingested rows without scanning the store per scrape.
+func watchDatasetGauges(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		refreshDatasetGauges()
+	}
+}
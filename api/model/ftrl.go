@@ -0,0 +1,101 @@
+// Package model holds learned scoring models used alongside the simpler
+// statistical comparisons in the api package.
+package model
+
+import (
+	"hash/fnv"
+	"math"
+	"sync"
+)
+
+// FTRLProximal is an online logistic regression model trained with the
+// FTRL-Proximal algorithm (McMahan et al.), as used for click-through-rate
+// prediction. Features are arbitrary strings hashed to a fixed-width ID, so
+// new feature types can be added without touching the weight storage.
+//
+// A single model is typically shared across concurrent HTTP handlers, so
+// Predict/Update guard the z/n maps with mu.
+type FTRLProximal struct {
+	alpha float64
+	beta  float64
+	l1    float64
+	l2    float64
+
+	mu sync.RWMutex
+	z  map[uint32]float64
+	n  map[uint32]float64
+}
+
+// NewFTRLProximal creates a model with the given learning-rate parameters
+// (alpha, beta) and L1/L2 regularization strengths.
+func NewFTRLProximal(alpha, beta, l1, l2 float64) *FTRLProximal {
+	return &FTRLProximal{
+		alpha: alpha,
+		beta:  beta,
+		l1:    l1,
+		l2:    l2,
+		z:     make(map[uint32]float64),
+		n:     make(map[uint32]float64),
+	}
+}
+
+// HashFeature maps a feature name (e.g. "h1=12") to its storage ID via
+// 32-bit FNV-1a.
+func HashFeature(name string) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	return h.Sum32()
+}
+
+func (m *FTRLProximal) weight(id uint32) float64 {
+	z := m.z[id]
+	if math.Abs(z) <= m.l1 {
+		return 0
+	}
+
+	sign := 1.0
+	if z < 0 {
+		sign = -1.0
+	}
+
+	return -(z - sign*m.l1) / ((m.beta+math.Sqrt(m.n[id]))/m.alpha + m.l2)
+}
+
+func sigmoid(x float64) float64 {
+	return 1 / (1 + math.Exp(-x))
+}
+
+func (m *FTRLProximal) predictLocked(features []string) float64 {
+	sum := 0.0
+	for _, f := range features {
+		sum += m.weight(HashFeature(f))
+	}
+	return sigmoid(sum)
+}
+
+// Predict returns p = sigmoid(sum of weights) for the given feature set.
+func (m *FTRLProximal) Predict(features []string) float64 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.predictLocked(features)
+}
+
+// Update observes a single labeled example (label in {0, 1}) and applies
+// one step of the FTRL-Proximal per-coordinate update.
+func (m *FTRLProximal) Update(features []string, label float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	p := m.predictLocked(features)
+	g := p - label
+
+	for _, f := range features {
+		id := HashFeature(f)
+		w := m.weight(id)
+		n := m.n[id]
+
+		sigma := (math.Sqrt(n+g*g) - math.Sqrt(n)) / m.alpha
+		m.z[id] += g - sigma*w
+		m.n[id] = n + g*g
+	}
+}
@@ -0,0 +1,114 @@
+package store
+
+import (
+	"encoding/csv"
+	"io"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Ingester tails a CSV file, storing any rows appended since the last
+// persisted offset (see Store.IngestOffset). Calling Poll once at startup
+// also performs the initial bulk load.
+type Ingester struct {
+	store  *Store
+	path   string
+	offset int64
+}
+
+// NewIngester creates an Ingester for path, resuming from whatever offset
+// was last persisted for it (0 for a brand-new store).
+func NewIngester(s *Store, path string) (*Ingester, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, err
+	}
+	offset, err := s.IngestOffset(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Ingester{store: s, path: path, offset: offset}, nil
+}
+
+// Watch calls Poll every interval until stop is closed.
+func (ing *Ingester) Watch(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			ing.Poll()
+		}
+	}
+}
+
+// Poll reads and stores any rows appended since the last successful Poll,
+// then advances the persisted offset. Malformed rows (including a header
+// row encountered at offset 0) are skipped rather than treated as fatal.
+func (ing *Ingester) Poll() error {
+	file, err := os.Open(ing.path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	if info.Size() <= ing.offset {
+		return nil
+	}
+
+	if _, err := file.Seek(ing.offset, io.SeekStart); err != nil {
+		return err
+	}
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	for {
+		line, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if len(line) < 3 {
+			continue
+		}
+
+		horseID, err := strconv.Atoi(line[0])
+		if err != nil {
+			continue
+		}
+		entryFee, err := strconv.ParseFloat(line[1], 64)
+		if err != nil {
+			continue
+		}
+		finishTime, err := strconv.ParseFloat(line[2], 64)
+		if err != nil {
+			continue
+		}
+
+		if err := ing.store.PutFinish(horseID, Finish{
+			Timestamp: time.Now().UnixNano(),
+			EntryFee:  entryFee,
+			Time:      finishTime,
+		}); err != nil {
+			return err
+		}
+	}
+
+	newOffset, err := file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	ing.offset = newOffset
+	return ing.store.SetIngestOffset(ing.path, newOffset)
+}
@@ -0,0 +1,177 @@
+// Package store provides an embedded, on-disk persistence layer for race
+// data backed by go.etcd.io/bbolt.
+package store
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Finish is a single recorded finish time for a horse.
+type Finish struct {
+	Timestamp int64   `json:"timestamp"`
+	EntryFee  float64 `json:"entryFee"`
+	Time      float64 `json:"time"`
+}
+
+// Race is a horse's full set of recorded finish times, as read back out of
+// the store.
+type Race struct {
+	HorseID     int
+	EntryFee    float64
+	FinishTimes []float64
+}
+
+// Store wraps a bbolt database with one bucket per horse, keyed by each
+// bucket's auto-incrementing sequence so range scans come back in insertion
+// order without a separate sort step.
+type Store struct {
+	db *bolt.DB
+}
+
+// Open opens (creating if necessary) the bbolt database at path.
+func Open(path string) (*Store, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 1 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open store: %v", err)
+	}
+	return &Store{db: db}, nil
+}
+
+// Close closes the underlying database.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+func bucketName(horseID int) []byte {
+	return []byte(fmt.Sprintf("horse:%d", horseID))
+}
+
+// metaBucket holds ingestion bookkeeping, kept out of Iterate's horse buckets.
+var metaBucket = []byte("_meta")
+
+// IngestOffset returns the last persisted byte offset for path, or 0.
+func (s *Store) IngestOffset(path string) (int64, error) {
+	var offset int64
+	err := s.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(metaBucket)
+		if bucket == nil {
+			return nil
+		}
+		v := bucket.Get([]byte(path))
+		if v == nil {
+			return nil
+		}
+		offset = int64(binary.BigEndian.Uint64(v))
+		return nil
+	})
+	return offset, err
+}
+
+// SetIngestOffset persists the byte offset ingested so far for path.
+func (s *Store) SetIngestOffset(path string, offset int64) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists(metaBucket)
+		if err != nil {
+			return err
+		}
+		v := make([]byte, 8)
+		binary.BigEndian.PutUint64(v, uint64(offset))
+		return bucket.Put([]byte(path), v)
+	})
+}
+
+func parseBucketName(name []byte) (int, bool) {
+	var horseID int
+	if _, err := fmt.Sscanf(string(name), "horse:%d", &horseID); err != nil {
+		return 0, false
+	}
+	return horseID, true
+}
+
+// PutFinish appends a single finish time for horseID, keyed by the bucket's
+// next sequence number. The sequence (not f.Timestamp) is what guarantees
+// key uniqueness and insertion order: two rows for the same horse can share
+// a nanosecond timestamp, but NextSequence is atomic per transaction even if
+// future callers batch multiple PutFinish calls into one.
+func (s *Store) PutFinish(horseID int, f Finish) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists(bucketName(horseID))
+		if err != nil {
+			return err
+		}
+
+		seq, err := bucket.NextSequence()
+		if err != nil {
+			return err
+		}
+		key := make([]byte, 8)
+		binary.BigEndian.PutUint64(key, seq)
+
+		value, err := json.Marshal(f)
+		if err != nil {
+			return err
+		}
+
+		return bucket.Put(key, value)
+	})
+}
+
+// GetRace reads back every finish time recorded for horseID, in insertion
+// order.
+func (s *Store) GetRace(horseID int) (Race, error) {
+	race := Race{HorseID: horseID}
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(bucketName(horseID))
+		if bucket == nil {
+			return fmt.Errorf("no race data for horse %d", horseID)
+		}
+
+		return bucket.ForEach(func(_, v []byte) error {
+			var f Finish
+			if err := json.Unmarshal(v, &f); err != nil {
+				return err
+			}
+			race.EntryFee = f.EntryFee
+			race.FinishTimes = append(race.FinishTimes, f.Time)
+			return nil
+		})
+	})
+
+	return race, err
+}
+
+// Iterate streams every horse's race in the store, in bucket order,
+// invoking fn with each horse's full finish-time history. It stops and
+// returns the first error fn returns.
+func (s *Store) Iterate(fn func(Race) error) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		return tx.ForEach(func(name []byte, bucket *bolt.Bucket) error {
+			horseID, ok := parseBucketName(name)
+			if !ok {
+				return nil
+			}
+
+			race := Race{HorseID: horseID}
+			err := bucket.ForEach(func(_, v []byte) error {
+				var f Finish
+				if err := json.Unmarshal(v, &f); err != nil {
+					return err
+				}
+				race.EntryFee = f.EntryFee
+				race.FinishTimes = append(race.FinishTimes, f.Time)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+
+			return fn(race)
+		})
+	})
+}
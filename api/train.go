@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"monte/model"
+)
+
+// matchupModel learns pairwise win probabilities between horses from
+// observed finish-time matchups; it is trained via /train and consumed by
+// /predict.
+var matchupModel = model.NewFTRLProximal(0.1, 1.0, 1.0, 1.0)
+
+func feeBucket(fee float64) string {
+	switch {
+	case fee < 10:
+		return "low"
+	case fee < 50:
+		return "mid"
+	default:
+		return "high"
+	}
+}
+
+// matchupFeatures builds the feature set for a horse1-vs-horse2 comparison.
+// No track feature yet — there's no track field in the data model.
+func matchupFeatures(h1, h2 Race) []string {
+	return []string{
+		fmt.Sprintf("h1=%d", h1.horseId),
+		fmt.Sprintf("h2=%d", h2.horseId),
+		fmt.Sprintf("fee_bucket=%s", feeBucket(h1.entryFee)),
+		fmt.Sprintf("h1=%d*h2=%d", h1.horseId, h2.horseId),
+	}
+}
+
+// trainHandler replays every pair of horses' finish times as matchup
+// examples (horse1 wins the example if its time at a given index beats
+// horse2's), streaming `epochs` passes through the in-memory race data into
+// matchupModel.
+func trainHandler(w http.ResponseWriter, r *http.Request) {
+	epochs := 1
+	if v := r.URL.Query().Get("epochs"); v != "" {
+		if e, err := strconv.Atoi(v); err == nil && e > 0 {
+			epochs = e
+		}
+	}
+
+	races, err := allRaces()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to load races: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	examples := 0
+	for e := 0; e < epochs; e++ {
+		for i := range races {
+			for j := range races {
+				if i == j {
+					continue
+				}
+
+				h1, h2 := races[i], races[j]
+				n := len(h1.finishTimes)
+				if len(h2.finishTimes) < n {
+					n = len(h2.finishTimes)
+				}
+
+				for k := 0; k < n; k++ {
+					label := 0.0
+					if h1.finishTimes[k] < h2.finishTimes[k] {
+						label = 1.0
+					}
+					matchupModel.Update(matchupFeatures(h1, h2), label)
+					examples++
+				}
+			}
+		}
+	}
+
+	response := fmt.Sprintf(`{"epochs": %d, "examples": %d}`, epochs, examples)
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(response))
+}
+
+// predictWinHandler returns the learned probability that horse1 beats
+// horse2, replacing the mean-speed comparison with matchupModel once it has
+// been trained.
+func predictWinHandler(w http.ResponseWriter, r *http.Request) {
+	h1, err := strconv.Atoi(r.URL.Query().Get("horse1"))
+	if err != nil {
+		http.Error(w, "Invalid horse1 parameter", http.StatusBadRequest)
+		return
+	}
+	h2, err := strconv.Atoi(r.URL.Query().Get("horse2"))
+	if err != nil {
+		http.Error(w, "Invalid horse2 parameter", http.StatusBadRequest)
+		return
+	}
+
+	h1Race, err := raceByID(h1)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("horse1 not found: %v", err), http.StatusNotFound)
+		return
+	}
+	h2Race, err := raceByID(h2)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("horse2 not found: %v", err), http.StatusNotFound)
+		return
+	}
+
+	p := matchupModel.Predict(matchupFeatures(h1Race, h2Race))
+
+	response := fmt.Sprintf(`{"horse1": %d, "horse2": %d, "horse1WinProbability": %f}`, h1, h2, p)
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(response))
+}